@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resultCache holds recently-resolved Results keyed by IP plus the set
+// of providers that produced them, so `chick serve` can skip repeating
+// PTR/provider lookups for an address it has already seen within ttl
+// without handing back data computed for a different provider set.
+type resultCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result  Result
+	expires time.Time
+}
+
+// newResultCache builds a cache that evicts expired entries lazily (on
+// the next write to the same key) and also proactively, via a
+// background sweep every ttl, so a long-running `chick serve` process
+// that sees a steady stream of distinct IPs doesn't grow entries
+// without bound.
+func newResultCache(ttl time.Duration) *resultCache {
+	c := &resultCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+	if ttl > 0 {
+		go c.sweepExpired()
+	}
+	return c
+}
+
+// sweepExpired periodically removes expired entries. It runs for the
+// lifetime of the process; resultCache has no Close because chick
+// serve creates exactly one and keeps it for as long as it's running.
+func (c *resultCache) sweepExpired() {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		c.mu.Lock()
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// cacheKey combines an IP with the sorted set of provider names that
+// will run against it, so two requests for the same IP with different
+// --providers selections never collide in the cache.
+func cacheKey(ip string, providers []Provider) string {
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name()
+	}
+	sort.Strings(names)
+	return ip + "|" + strings.Join(names, ",")
+}
+
+// get returns the cached Result for ip+providers, if present and not expired.
+func (c *resultCache) get(ip string, providers []Provider) (Result, bool) {
+	if c.ttl <= 0 {
+		return Result{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[cacheKey(ip, providers)]
+	if !ok || time.Now().After(entry.expires) {
+		return Result{}, false
+	}
+	return entry.result, true
+}
+
+func (c *resultCache) set(ip string, providers []Provider, result Result) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[cacheKey(ip, providers)] = cacheEntry{result: result, expires: time.Now().Add(c.ttl)}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheKeyIsOrderIndependent(t *testing.T) {
+	a := []Provider{&ipinfoProvider{}, &teamCymruProvider{}}
+	b := []Provider{&teamCymruProvider{}, &ipinfoProvider{}}
+
+	if cacheKey("192.0.2.1", a) != cacheKey("192.0.2.1", b) {
+		t.Fatal("expected cache key to be independent of provider order")
+	}
+}
+
+func TestCacheKeyDiffersByProviderSet(t *testing.T) {
+	onlyIPInfo := cacheKey("192.0.2.1", []Provider{&ipinfoProvider{}})
+	both := cacheKey("192.0.2.1", []Provider{&ipinfoProvider{}, &teamCymruProvider{}})
+
+	if onlyIPInfo == both {
+		t.Fatal("expected different provider sets to produce different cache keys")
+	}
+}
+
+func TestResultCacheMissesOnDifferentProviderSet(t *testing.T) {
+	c := newResultCache(time.Minute)
+	ipinfoOnly := []Provider{&ipinfoProvider{}}
+	both := []Provider{&ipinfoProvider{}, &teamCymruProvider{}}
+
+	c.set("192.0.2.1", ipinfoOnly, Result{IP: "192.0.2.1"})
+
+	if _, ok := c.get("192.0.2.1", ipinfoOnly); !ok {
+		t.Fatal("expected a hit for the exact provider set that populated the cache")
+	}
+	if _, ok := c.get("192.0.2.1", both); ok {
+		t.Fatal("expected a miss when the requested provider set differs from what produced the entry")
+	}
+}
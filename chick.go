@@ -11,6 +11,15 @@ Features:
   - Fetch I-line information using IRCnet API
   - Support for IPv4 and IPv6 addresses
   - Colorized output for better readability
+  - Batch mode: read many targets from a file or stdin with -f
+  - Bounded worker pool (--workers) so large batches stay memory-flat
+  - Structured output formats (text, json, ndjson, csv) via --format/-o
+  - Pluggable enrichment providers (ipinfo, ircnet-iline, ripe-stat,
+    team-cymru, abuseipdb) selectable via --providers/--exclude-providers
+  - Custom resolvers via --resolver, --doh, --dot or --resolvers-file
+  - Live streaming of results via --stream (default for non-text formats)
+  - "chick serve" daemon mode exposing lookups over gRPC and REST, with
+    a TTL cache so repeated queries skip redundant provider calls
   - Parallel processing using goroutines
   - Configurable timeout handling for HTTP requests
   - Graceful shutdown on user interrupt
@@ -25,6 +34,7 @@ License: MIT License (https://kofany.mit-license.org)
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -35,6 +45,7 @@ import (
 	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -43,11 +54,45 @@ import (
 )
 
 var CLI struct {
-	IPv4         bool          `help:"Show only IPv4 (A) records" short:"4"`
-	IPv6         bool          `help:"Show only IPv6 (AAAA) records" short:"6"`
-	Timeout      time.Duration `help:"Timeout for HTTP requests" default:"5s"`
-	ILineTimeout time.Duration `help:"Timeout for I-line API requests" default:"10s"`
-	Target       string        `arg name:"domain/ip" help:"Domain, subdomain or IP to check"`
+	IPv4             bool          `help:"Show only IPv4 (A) records" short:"4"`
+	IPv6             bool          `help:"Show only IPv6 (AAAA) records" short:"6"`
+	Timeout          time.Duration `help:"Timeout for HTTP requests" default:"5s"`
+	ILineTimeout     time.Duration `help:"Timeout for I-line API requests" default:"10s"`
+	Workers          int           `help:"Number of concurrent workers when resolving multiple targets" default:"20"`
+	InputFile        string        `help:"Read targets (domain/IP, one per line) from file, or '-' for stdin" short:"f"`
+	Output           string        `help:"Write results to this file instead of stdout" short:"o"`
+	Format           string        `help:"Output format" enum:"text,json,ndjson,csv" default:"text"`
+	Providers        string        `help:"Comma-separated list of enrichment providers to run" default:"ipinfo,ircnet-iline"`
+	ExcludeProviders string        `help:"Comma-separated list of providers to skip"`
+	AbuseIPDBKey     string        `help:"API key for the abuseipdb provider"`
+	Config           string        `help:"Path to config file (per-provider timeouts/keys)" type:"path"`
+	Resolver         string        `help:"Custom DNS resolver, e.g. 1.1.1.1:53 or tcp://9.9.9.9:53" placeholder:"ADDR"`
+	DOH              string        `help:"DNS-over-HTTPS resolver URL, e.g. https://cloudflare-dns.com/dns-query" name:"doh" placeholder:"URL"`
+	DOT              string        `help:"DNS-over-TLS resolver, e.g. 9.9.9.9:853" name:"dot" placeholder:"HOST:PORT"`
+	ResolversFile    string        `help:"File with one resolver address per line; queries round-robin across them"`
+	Stream           bool          `help:"Print each result as it arrives instead of waiting for the run to finish (default for non-text formats)"`
+	Quiet            bool          `help:"Suppress the progress indicator" short:"q"`
+
+	Lookup LookupCmd `cmd:"" default:"withargs" hidden:"" help:"Resolve a domain/IP (the default when no subcommand is given)"`
+	Serve  ServeCmd  `cmd:"" help:"Run chick as a long-lived service exposing lookups over gRPC and HTTP"`
+}
+
+// LookupCmd is kong's default command: it's what runs when chick is
+// invoked with a bare target/flags and no subcommand name, e.g.
+// `chick example.com` or `chick -f targets.txt`. Kong doesn't allow a
+// top-level positional arg to live alongside branching commands like
+// Serve, so the positional target lives here instead.
+type LookupCmd struct {
+	Target string `arg:"" name:"domain/ip" optional:"" help:"Domain, subdomain or IP to check"`
+}
+
+// lookupTask is one unit of work handed to a worker: a single IP to
+// resolve, tagged with the target it was resolved from so results can
+// still be attributed when scanning many inputs at once.
+type lookupTask struct {
+	ip           string
+	isIPv6       bool
+	originTarget string
 }
 
 type IPInfo struct {
@@ -64,12 +109,12 @@ type ILineInfo struct {
 }
 
 type Result struct {
-	IP     string
-	PTR    []string
-	IPInfo *IPInfo
-	ILine  []string
-	IsIPv6 bool
-	Error  error
+	IP           string
+	PTR          []string
+	Providers    map[string]map[string]interface{}
+	IsIPv6       bool
+	OriginTarget string
+	Error        error
 }
 
 var (
@@ -141,96 +186,175 @@ func getILineInfo(ctx context.Context, ip string) ([]string, error) {
 	return servers, nil
 }
 
-func lookupIP(ctx context.Context, ip string, isIPv6 bool, resultChan chan<- Result, wg *sync.WaitGroup) {
-	defer wg.Done()
-	result := Result{IP: ip, IsIPv6: isIPv6}
-
-	var wgInternal sync.WaitGroup
-	wgInternal.Add(3)
-
-	go func() {
-		defer wgInternal.Done()
-		names, err := net.LookupAddr(ip)
-		if err != nil {
-			result.Error = fmt.Errorf("error looking up PTR records: %v", err)
-		} else {
-			result.PTR = names
-		}
-	}()
+// lookupIP performs the PTR lookup plus every active provider's Enrich
+// call for a single IP, sequentially. It is called from within a
+// fixed-size worker pool, so unlike the original per-target fan-out it
+// no longer needs its own internal goroutines to stay fast: concurrency
+// now comes from running many workers, not from parallelizing every
+// single IP.
+func lookupIP(ctx context.Context, task lookupTask, providers []Provider) Result {
+	result := Result{
+		IP:           task.ip,
+		IsIPv6:       task.isIPv6,
+		OriginTarget: task.originTarget,
+		Providers:    make(map[string]map[string]interface{}, len(providers)),
+	}
 
-	go func() {
-		defer wgInternal.Done()
-		ipInfo, err := getIPInfo(ctx, ip)
-		if err != nil {
-			if result.Error != nil {
-				result.Error = fmt.Errorf("%v; error fetching IP info: %v", result.Error, err)
-			} else {
-				result.Error = fmt.Errorf("error fetching IP info: %v", err)
-			}
-		} else {
-			result.IPInfo = ipInfo
-		}
-	}()
+	names, err := lookupAddr(ctx, task.ip)
+	if err != nil {
+		result.Error = fmt.Errorf("error looking up PTR records: %v", err)
+	} else {
+		result.PTR = names
+	}
 
-	go func() {
-		defer wgInternal.Done()
-		iLine, err := getILineInfo(ctx, ip)
+	for _, provider := range providers {
+		data, err := provider.Enrich(ctx, task.ip)
 		if err != nil {
+			wrapped := fmt.Errorf("error from %s provider: %v", provider.Name(), err)
 			if result.Error != nil {
-				result.Error = fmt.Errorf("%v; error fetching I-line info: %v", result.Error, err)
+				result.Error = fmt.Errorf("%v; %v", result.Error, wrapped)
 			} else {
-				result.Error = fmt.Errorf("error fetching I-line info: %v", err)
+				result.Error = wrapped
 			}
-		} else {
-			result.ILine = iLine
+			continue
 		}
-	}()
+		result.Providers[provider.Name()] = data
+	}
 
-	wgInternal.Wait()
-	resultChan <- result
+	return result
 }
 
-func printResult(result Result) {
-	recordType := "A"
-	if result.IsIPv6 {
-		recordType = "AAAA"
+// lookupWorker drains taskChan until it is closed, resolving each task in
+// turn and publishing the Result on resultChan. Running a fixed number of
+// these instead of one goroutine set per target keeps memory and file
+// descriptor usage flat regardless of how many IPs are queued.
+func lookupWorker(ctx context.Context, taskChan <-chan lookupTask, resultChan chan<- Result, providers []Provider, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for task := range taskChan {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		resultChan <- lookupIP(ctx, task, providers)
 	}
-	fmt.Printf("%s: %s\n", cyan(fmt.Sprintf("%s Record", recordType)), yellow(result.IP))
+}
 
-	if len(result.PTR) > 0 {
-		fmt.Printf("  %s: %s\n", cyan("PTR Records"), green(strings.Join(result.PTR, ", ")))
+// readTargets loads targets from CLI.InputFile (one domain/IP per line,
+// blank lines and '#'-prefixed comments ignored), falling back to
+// CLI.Lookup.Target when no file was given. A path of "-" reads from stdin.
+func readTargets() ([]string, error) {
+	if CLI.InputFile == "" {
+		return []string{CLI.Lookup.Target}, nil
 	}
 
-	if result.IPInfo != nil {
-		fmt.Printf("  %s: %s\n", cyan("Country"), green(result.IPInfo.Country))
-		fmt.Printf("  %s: %s\n", cyan("Organization"), green(result.IPInfo.Org))
+	var src *os.File
+	if CLI.InputFile == "-" {
+		src = os.Stdin
+	} else {
+		f, err := os.Open(CLI.InputFile)
+		if err != nil {
+			return nil, fmt.Errorf("error opening input file: %v", err)
+		}
+		defer f.Close()
+		src = f
 	}
 
-	if len(result.ILine) > 0 {
-		fmt.Printf("  %s: %s\n", cyan("I-Line Servers"), green(strings.Join(result.ILine, ", ")))
+	var targets []string
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
 	}
-
-	if result.Error != nil {
-		fmt.Printf("  %s: %s\n", red("Error"), red(result.Error.Error()))
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input file: %v", err)
 	}
-
-	fmt.Println()
+	return targets, nil
 }
 
-func validateInput(input string) error {
-	if net.ParseIP(input) != nil {
-		return nil
+// lookupIPs resolves a domain/IP target to its addresses, going through
+// resolverInstance when a custom resolver (--resolver/--doh/--dot/
+// --resolvers-file) was configured, or the system resolver otherwise.
+func lookupIPs(ctx context.Context, target string) ([]net.IP, error) {
+	if resolverInstance != nil {
+		return resolverInstance.lookupIPAddr(ctx, target)
 	}
-	if _, err := net.LookupHost(input); err != nil {
-		return fmt.Errorf("invalid domain or IP address: %v", err)
+	return net.LookupIP(target)
+}
+
+// lookupAddr resolves PTR records for ip, through resolverInstance when
+// configured, or the system resolver otherwise.
+func lookupAddr(ctx context.Context, ip string) ([]string, error) {
+	if resolverInstance != nil {
+		return resolverInstance.lookupAddr(ctx, ip)
 	}
-	return nil
+	return net.LookupAddr(ip)
 }
 
 func main() {
 	ctx := kong.Parse(&CLI)
 
-	if err := validateInput(CLI.Target); err != nil {
+	if strings.HasPrefix(ctx.Command(), "serve") {
+		if err := CLI.Serve.Run(); err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), red(err))
+			ctx.Exit(1)
+		}
+		return
+	}
+
+	if CLI.InputFile == "" && CLI.Lookup.Target == "" {
+		fmt.Printf("%s: no target given; pass a domain/IP or -f <file>\n", red("Error"))
+		ctx.Exit(1)
+	}
+	if CLI.Workers < 1 {
+		fmt.Printf("%s: --workers must be at least 1\n", red("Error"))
+		ctx.Exit(1)
+	}
+
+	targets, err := readTargets()
+	if err != nil {
+		fmt.Printf("%s: %v\n", red("Error"), red(err))
+		ctx.Exit(1)
+	}
+
+	if CLI.DOH != "" {
+		if err := validateDoHURL(CLI.DOH); err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), red(err))
+			ctx.Exit(1)
+		}
+	}
+	resolver, err := buildResolver()
+	if err != nil {
+		fmt.Printf("%s: %v\n", red("Error"), red(err))
+		ctx.Exit(1)
+	}
+	resolverInstance = resolver
+
+	out, err := newOutputWriter(CLI.Output, CLI.Format)
+	if err != nil {
+		fmt.Printf("%s: %v\n", red("Error"), red(err))
+		ctx.Exit(1)
+	}
+	defer out.Close()
+
+	configPath := CLI.Config
+	if configPath == "" {
+		configPath = defaultConfigPath()
+	}
+	if configPath != "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), red(err))
+			ctx.Exit(1)
+		}
+		appConfig = cfg
+	}
+
+	providers, err := resolveProviders(CLI.Providers, CLI.ExcludeProviders)
+	if err != nil {
 		fmt.Printf("%s: %v\n", red("Error"), red(err))
 		ctx.Exit(1)
 	}
@@ -249,66 +373,96 @@ func main() {
 		cancel()
 	}()
 
-	ip := net.ParseIP(CLI.Target)
-
-	var wg sync.WaitGroup
+	taskChan := make(chan lookupTask, CLI.Workers*2)
 	resultChan := make(chan Result, 10) // Buffered channel
 	var results []Result
 
-	var ips []net.IP
-	if ip != nil {
-		ips = append(ips, ip)
-	} else {
-		var err error
-		ips, err = net.LookupIP(CLI.Target)
-		if err != nil {
-			fmt.Printf("%s: %v\n", red("Error looking up IP for domain"), red(err))
-			ctx.Exit(1)
-		}
-	}
+	// Producer: resolve each target to its IPs and enqueue one task per
+	// IP. This runs independently of the worker pool so resolving target
+	// #50 never waits on target #1's enrichment calls finishing.
+	var producerWg sync.WaitGroup
+	producerWg.Add(1)
+	var totalIPs atomic.Int64
+	go func() {
+		defer producerWg.Done()
+		defer close(taskChan)
+		for _, target := range targets {
+			var ips []net.IP
+			if parsed := net.ParseIP(target); parsed != nil {
+				ips = []net.IP{parsed}
+			} else {
+				resolved, err := lookupIPs(mainCtx, target)
+				if err != nil {
+					totalIPs.Add(1)
+					errResult := Result{OriginTarget: target, Error: fmt.Errorf("error looking up IP for domain %q: %v", target, err)}
+					select {
+					case resultChan <- errResult:
+					case <-mainCtx.Done():
+						return
+					}
+					continue
+				}
+				ips = resolved
+			}
 
-	totalIPs := 0
-	for _, ip := range ips {
-		isIPv6 := ip.To4() == nil
-		if (CLI.IPv4 && !isIPv6) || (CLI.IPv6 && isIPv6) || (!CLI.IPv4 && !CLI.IPv6) {
-			totalIPs++
-			wg.Add(1)
-			go lookupIP(mainCtx, ip.String(), isIPv6, resultChan, &wg)
+			for _, ip := range ips {
+				isIPv6 := ip.To4() == nil
+				if (CLI.IPv4 && !isIPv6) || (CLI.IPv6 && isIPv6) || (!CLI.IPv4 && !CLI.IPv6) {
+					totalIPs.Add(1)
+					select {
+					case taskChan <- lookupTask{ip: ip.String(), isIPv6: isIPv6, originTarget: target}:
+					case <-mainCtx.Done():
+						return
+					}
+				}
+			}
 		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < CLI.Workers; i++ {
+		wg.Add(1)
+		go lookupWorker(mainCtx, taskChan, resultChan, providers, &wg)
 	}
 
 	go func() {
+		producerWg.Wait()
 		wg.Wait()
 		close(resultChan)
 	}()
 
-	done := make(chan bool)
-	go func() {
-		for result := range resultChan {
-			results = append(results, result)
-		}
-		close(done)
-	}()
-
-	fmt.Print(yellow("Checking records... Please wait"))
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+	stream := CLI.Stream || CLI.Format != "text"
+	progress := newProgressReporter(!CLI.Quiet && stdoutIsTerminal(), &totalIPs)
+	progress.Start(progress.Redraw)
+	defer progress.Stop()
 
-	processed := 0
 	for {
 		select {
 		case <-mainCtx.Done():
 			fmt.Println("\nOperation cancelled")
 			return
-		case <-done:
-			fmt.Print("\r" + strings.Repeat(" ", 60) + "\r") // Clear the progress message
-			for _, result := range results {
-				printResult(result)
+		case result, ok := <-resultChan:
+			if !ok {
+				progress.Stop()
+				if !stream {
+					for _, result := range results {
+						if err := out.Write(result); err != nil {
+							fmt.Printf("%s: %v\n", red("Error"), red(err))
+						}
+					}
+				}
+				return
+			}
+			progress.Increment()
+			if stream {
+				progress.WithPaused(func() {
+					if err := out.Write(result); err != nil {
+						fmt.Printf("%s: %v\n", red("Error"), red(err))
+					}
+				})
+			} else {
+				results = append(results, result)
 			}
-			return
-		case <-ticker.C:
-			processed = len(results)
-			fmt.Printf("\rChecking records... %d/%d completed", processed, totalIPs)
 		}
 	}
 }
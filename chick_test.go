@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// withStubResolver points resolverInstance at a resolver that answers
+// every query with an empty (no-records) response, echoing back the
+// query's transaction ID, so worker tests don't reach out over the
+// network. It restores the previous resolverInstance on cleanup.
+func withStubResolver(t *testing.T) {
+	t.Helper()
+	prev := resolverInstance
+	resolverInstance = &dnsResolver{
+		query: func(ctx context.Context, query []byte) ([]byte, error) {
+			id := binary.BigEndian.Uint16(query[0:2])
+			resp := make([]byte, 12)
+			binary.BigEndian.PutUint16(resp[0:2], id)
+			return resp, nil
+		},
+	}
+	t.Cleanup(func() { resolverInstance = prev })
+}
+
+// withCLIInput temporarily overrides the CLI globals readTargets reads
+// from, restoring them once the test finishes.
+func withCLIInput(t *testing.T, inputFile, target string) {
+	t.Helper()
+	prevFile, prevTarget := CLI.InputFile, CLI.Lookup.Target
+	CLI.InputFile, CLI.Lookup.Target = inputFile, target
+	t.Cleanup(func() { CLI.InputFile, CLI.Lookup.Target = prevFile, prevTarget })
+}
+
+func TestReadTargetsFallsBackToLookupTarget(t *testing.T) {
+	withCLIInput(t, "", "example.com")
+
+	targets, err := readTargets()
+	if err != nil {
+		t.Fatalf("readTargets returned error: %v", err)
+	}
+	if len(targets) != 1 || targets[0] != "example.com" {
+		t.Fatalf("expected [example.com], got %v", targets)
+	}
+}
+
+func TestReadTargetsFromFileSkipsBlankLinesAndComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	contents := "example.com\n\n# a comment\n  \n192.0.2.1\n   # indented comment\nexample.org\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	withCLIInput(t, path, "")
+
+	targets, err := readTargets()
+	if err != nil {
+		t.Fatalf("readTargets returned error: %v", err)
+	}
+	want := []string{"example.com", "192.0.2.1", "example.org"}
+	if len(targets) != len(want) {
+		t.Fatalf("got %v, want %v", targets, want)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Fatalf("got %v, want %v", targets, want)
+		}
+	}
+}
+
+func TestReadTargetsMissingFileReturnsError(t *testing.T) {
+	withCLIInput(t, filepath.Join(t.TempDir(), "does-not-exist.txt"), "")
+
+	if _, err := readTargets(); err == nil {
+		t.Fatal("expected error for missing input file, got nil")
+	}
+}
+
+func TestReadTargetsFromStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	prevStdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = prevStdin })
+
+	withCLIInput(t, "-", "")
+
+	go func() {
+		w.WriteString("example.com\n# skip me\n192.0.2.1\n")
+		w.Close()
+	}()
+
+	targets, err := readTargets()
+	if err != nil {
+		t.Fatalf("readTargets returned error: %v", err)
+	}
+	want := []string{"example.com", "192.0.2.1"}
+	if len(targets) != len(want) {
+		t.Fatalf("got %v, want %v", targets, want)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Fatalf("got %v, want %v", targets, want)
+		}
+	}
+}
+
+func TestLookupWorkerDrainsTasksAndPublishesResults(t *testing.T) {
+	withStubResolver(t)
+
+	taskChan := make(chan lookupTask, 2)
+	resultChan := make(chan Result, 2)
+	taskChan <- lookupTask{ip: "192.0.2.1", originTarget: "192.0.2.1"}
+	taskChan <- lookupTask{ip: "192.0.2.2", originTarget: "192.0.2.2"}
+	close(taskChan)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go lookupWorker(context.Background(), taskChan, resultChan, nil, &wg)
+	wg.Wait()
+	close(resultChan)
+
+	var got []string
+	for result := range resultChan {
+		got = append(got, result.IP)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 results, got %v", got)
+	}
+}
+
+func TestLookupWorkerStopsOnCancelledContext(t *testing.T) {
+	taskChan := make(chan lookupTask, 1)
+	resultChan := make(chan Result, 1)
+	taskChan <- lookupTask{ip: "192.0.2.1", originTarget: "192.0.2.1"}
+	close(taskChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go lookupWorker(ctx, taskChan, resultChan, nil, &wg)
+	wg.Wait()
+
+	select {
+	case result := <-resultChan:
+		t.Fatalf("expected no result once context is cancelled, got %v", result)
+	default:
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProviderSettings holds the per-provider overrides that can be set in
+// the config file but have no dedicated CLI flag (e.g. request timeouts).
+type ProviderSettings struct {
+	Timeout time.Duration `yaml:"timeout"`
+	APIKey  string        `yaml:"api_key"`
+}
+
+// Config is the shape of ~/.config/chick/config.yaml.
+type Config struct {
+	Providers map[string]ProviderSettings `yaml:"providers"`
+}
+
+// appConfig is the loaded config file, or nil if none was found/given.
+// Providers read from it via providerTimeout and their own APIKey checks.
+var appConfig *Config
+
+// defaultConfigPath returns ~/.config/chick/config.yaml, matching the
+// XDG-style layout used by other lookup tools.
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "chick", "config.yaml")
+}
+
+// loadConfig reads and parses the config file at path. A missing file at
+// the default location is not an error: config is entirely optional.
+func loadConfig(path string) (*Config, error) {
+	isDefault := path == defaultConfigPath()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && isDefault {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+	if cfg.Providers == nil {
+		cfg.Providers = map[string]ProviderSettings{}
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// resultJSON is the wire shape used by the json/ndjson writers. Result
+// itself can't be marshalled directly because its Error field is the
+// error interface, not a string.
+type resultJSON struct {
+	IP           string                            `json:"ip"`
+	PTR          []string                          `json:"ptr,omitempty"`
+	Providers    map[string]map[string]interface{} `json:"providers,omitempty"`
+	IsIPv6       bool                              `json:"is_ipv6"`
+	OriginTarget string                            `json:"origin_target,omitempty"`
+	Error        string                            `json:"error,omitempty"`
+}
+
+func toResultJSON(result Result) resultJSON {
+	rj := resultJSON{
+		IP:           result.IP,
+		PTR:          result.PTR,
+		Providers:    result.Providers,
+		IsIPv6:       result.IsIPv6,
+		OriginTarget: result.OriginTarget,
+	}
+	if result.Error != nil {
+		rj.Error = result.Error.Error()
+	}
+	return rj
+}
+
+// OutputWriter renders Results as they become available. Implementations
+// must be safe to call Write on repeatedly and Close exactly once when
+// the run is finished.
+type OutputWriter interface {
+	Write(result Result) error
+	Close() error
+}
+
+// newOutputWriter opens the configured output destination (a file, or
+// stdout when path is empty) and wraps it with the writer for format.
+// Color is disabled whenever the destination isn't a text format or
+// isn't a terminal, since escape codes have no place in JSON/CSV or in
+// a file meant for other tools to parse.
+func newOutputWriter(path, format string) (OutputWriter, error) {
+	var w io.Writer = os.Stdout
+	var closer io.Closer
+	isTerminal := true
+
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening output file: %v", err)
+		}
+		w = f
+		closer = f
+		isTerminal = false
+	} else {
+		isTerminal = stdoutIsTerminal()
+	}
+
+	if format != "text" || !isTerminal {
+		color.NoColor = true
+	}
+
+	switch format {
+	case "text":
+		return &textWriter{w: w, closer: closer}, nil
+	case "json":
+		return &jsonWriter{w: w, closer: closer}, nil
+	case "ndjson":
+		return &ndjsonWriter{w: w, closer: closer}, nil
+	case "csv":
+		return &csvWriter{cw: csv.NewWriter(w), closer: closer}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// stdoutIsTerminal reports whether stdout looks like an interactive
+// terminal rather than a pipe or redirected file.
+func stdoutIsTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// textWriter reproduces the original human-readable console output.
+type textWriter struct {
+	w      io.Writer
+	closer io.Closer
+}
+
+func (t *textWriter) Write(result Result) error {
+	recordType := "A"
+	if result.IsIPv6 {
+		recordType = "AAAA"
+	}
+	if result.OriginTarget != "" && result.OriginTarget != result.IP {
+		fmt.Fprintf(t.w, "%s: %s (%s)\n", cyan(fmt.Sprintf("%s Record", recordType)), yellow(result.IP), magenta(result.OriginTarget))
+	} else {
+		fmt.Fprintf(t.w, "%s: %s\n", cyan(fmt.Sprintf("%s Record", recordType)), yellow(result.IP))
+	}
+
+	if len(result.PTR) > 0 {
+		fmt.Fprintf(t.w, "  %s: %s\n", cyan("PTR Records"), green(strings.Join(result.PTR, ", ")))
+	}
+
+	t.writeProviderSections(result)
+
+	if result.Error != nil {
+		fmt.Fprintf(t.w, "  %s: %s\n", red("Error"), red(result.Error.Error()))
+	}
+
+	fmt.Fprintln(t.w)
+	return nil
+}
+
+// writeProviderSections prints each provider's enrichment data. ipinfo
+// and ircnet-iline keep the original field labels for readability;
+// anything else is rendered as a generic "name: key=value, ..." line.
+func (t *textWriter) writeProviderSections(result Result) {
+	if ipinfo, ok := result.Providers["ipinfo"]; ok {
+		fmt.Fprintf(t.w, "  %s: %v\n", cyan("Country"), green(ipinfo["country"]))
+		fmt.Fprintf(t.w, "  %s: %v\n", cyan("Organization"), green(ipinfo["org"]))
+	}
+
+	if iline, ok := result.Providers["ircnet-iline"]; ok {
+		if servers, ok := iline["servers"].([]string); ok && len(servers) > 0 {
+			fmt.Fprintf(t.w, "  %s: %s\n", cyan("I-Line Servers"), green(strings.Join(servers, ", ")))
+		}
+	}
+
+	for _, name := range sortedProviderNames() {
+		if name == "ipinfo" || name == "ircnet-iline" {
+			continue
+		}
+		data, ok := result.Providers[name]
+		if !ok {
+			continue
+		}
+
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, data[k]))
+		}
+		fmt.Fprintf(t.w, "  %s: %s\n", cyan(name), green(strings.Join(pairs, ", ")))
+	}
+}
+
+func (t *textWriter) Close() error {
+	if t.closer != nil {
+		return t.closer.Close()
+	}
+	return nil
+}
+
+// jsonWriter buffers every Result and emits a single top-level JSON array
+// on Close, since a valid JSON document can't be streamed incrementally.
+type jsonWriter struct {
+	w       io.Writer
+	closer  io.Closer
+	results []resultJSON
+}
+
+func (j *jsonWriter) Write(result Result) error {
+	j.results = append(j.results, toResultJSON(result))
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	if j.results == nil {
+		j.results = []resultJSON{}
+	}
+	if err := enc.Encode(j.results); err != nil {
+		return err
+	}
+	if j.closer != nil {
+		return j.closer.Close()
+	}
+	return nil
+}
+
+// ndjsonWriter emits one JSON object per line as soon as each Result
+// arrives, so it can be piped straight into jq or another consumer
+// without waiting for the run to finish.
+type ndjsonWriter struct {
+	w      io.Writer
+	closer io.Closer
+}
+
+func (n *ndjsonWriter) Write(result Result) error {
+	enc := json.NewEncoder(n.w)
+	return enc.Encode(toResultJSON(result))
+}
+
+func (n *ndjsonWriter) Close() error {
+	if n.closer != nil {
+		return n.closer.Close()
+	}
+	return nil
+}
+
+// csvWriter produces a flat table, one row per IP.
+type csvWriter struct {
+	cw          *csv.Writer
+	closer      io.Closer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(result Result) error {
+	if !c.wroteHeader {
+		if err := c.cw.Write([]string{"ip", "record_type", "ptr", "country", "org", "iline_servers", "error"}); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+
+	recordType := "A"
+	if result.IsIPv6 {
+		recordType = "AAAA"
+	}
+	var country, org string
+	if ipinfo, ok := result.Providers["ipinfo"]; ok {
+		country, _ = ipinfo["country"].(string)
+		org, _ = ipinfo["org"].(string)
+	}
+	var ilineServers []string
+	if iline, ok := result.Providers["ircnet-iline"]; ok {
+		ilineServers, _ = iline["servers"].([]string)
+	}
+	var errStr string
+	if result.Error != nil {
+		errStr = result.Error.Error()
+	}
+
+	// The CSV table only has columns for the two original providers;
+	// other providers (ripe-stat, team-cymru, abuseipdb, ...) are
+	// available in the json/ndjson formats instead.
+	row := []string{
+		result.IP,
+		recordType,
+		strings.Join(result.PTR, ";"),
+		country,
+		org,
+		strings.Join(ilineServers, ";"),
+		errStr,
+	}
+	if err := c.cw.Write(row); err != nil {
+		return err
+	}
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	if err := c.cw.Error(); err != nil {
+		return err
+	}
+	if c.closer != nil {
+		return c.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestToResultJSONCarriesErrorAsString(t *testing.T) {
+	result := Result{IP: "192.0.2.1", OriginTarget: "example.com", Error: errors.New("boom")}
+
+	rj := toResultJSON(result)
+	if rj.Error != "boom" {
+		t.Fatalf("expected error string %q, got %q", "boom", rj.Error)
+	}
+	if rj.IP != result.IP || rj.OriginTarget != result.OriginTarget {
+		t.Fatalf("unexpected resultJSON: %+v", rj)
+	}
+}
+
+func TestJSONWriterEmitsArrayOnClose(t *testing.T) {
+	var buf bytes.Buffer
+	w := &jsonWriter{w: &buf}
+
+	if err := w.Write(Result{IP: "192.0.2.1"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := w.Write(Result{IP: "192.0.2.2"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	var decoded []resultJSON
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a valid JSON array: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(decoded))
+	}
+}
+
+func TestJSONWriterEmitsEmptyArrayWhenNoResults(t *testing.T) {
+	var buf bytes.Buffer
+	w := &jsonWriter{w: &buf}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Fatalf("expected empty JSON array, got %q", got)
+	}
+}
+
+func TestNDJSONWriterEmitsOneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := &ndjsonWriter{w: &buf}
+
+	if err := w.Write(Result{IP: "192.0.2.1"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := w.Write(Result{IP: "192.0.2.2"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var rj resultJSON
+		if err := json.Unmarshal([]byte(line), &rj); err != nil {
+			t.Fatalf("line is not valid JSON: %v", err)
+		}
+	}
+}
+
+func TestCSVWriterWritesHeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	w := &csvWriter{cw: csv.NewWriter(&buf)}
+
+	if err := w.Write(Result{IP: "192.0.2.1", PTR: []string{"a.example.com."}}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := w.Write(Result{IP: "192.0.2.2", Error: errors.New("no records")}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d records", len(records))
+	}
+	if records[0][0] != "ip" {
+		t.Fatalf("expected header row, got %v", records[0])
+	}
+	if records[2][6] != "no records" {
+		t.Fatalf("expected error column to carry the error text, got %v", records[2])
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressReporter draws a single-line "N/total completed" progress
+// indicator that can be paused and resumed around other output, so it
+// can coexist with results being streamed to the console as they
+// arrive instead of only appearing once the whole run finishes.
+type ProgressReporter struct {
+	enabled   bool
+	total     *atomic.Int64
+	processed atomic.Int64
+	ticker    *time.Ticker
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	// mu serializes terminal writes between the background ticker and
+	// WithPaused, so the ticker can never redraw the progress line in
+	// the middle of a caller printing a streamed result over it.
+	mu sync.Mutex
+}
+
+// newProgressReporter builds a reporter for the given total counter.
+// enabled is typically false for --quiet or non-TTY output, in which
+// case every method becomes a no-op.
+func newProgressReporter(enabled bool, total *atomic.Int64) *ProgressReporter {
+	return &ProgressReporter{enabled: enabled, total: total, stop: make(chan struct{})}
+}
+
+// Start prints the initial message and, if onTick is non-nil, begins a
+// background ticker that calls onTick every 500ms until Stop is called.
+func (p *ProgressReporter) Start(onTick func()) {
+	if !p.enabled {
+		return
+	}
+	fmt.Print(yellow("Checking records... Please wait"))
+	if onTick == nil {
+		return
+	}
+	p.ticker = time.NewTicker(500 * time.Millisecond)
+	go func() {
+		for {
+			select {
+			case <-p.ticker.C:
+				onTick()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Increment records that one more result has been processed.
+func (p *ProgressReporter) Increment() {
+	p.processed.Add(1)
+}
+
+// Redraw overwrites the current line with the latest progress count.
+func (p *ProgressReporter) Redraw() {
+	if !p.enabled {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.redrawLocked()
+}
+
+func (p *ProgressReporter) redrawLocked() {
+	fmt.Printf("\rChecking records... %d/%d completed", p.processed.Load(), p.total.Load())
+}
+
+// Pause erases the progress line so other output can be printed in its
+// place without the two interleaving garbage onto the terminal.
+//
+// Callers that print something and then want the progress line back
+// should use WithPaused instead: a bare Pause/Redraw pair leaves a
+// window where the background ticker can redraw between them.
+func (p *ProgressReporter) Pause() {
+	if !p.enabled {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pauseLocked()
+}
+
+func (p *ProgressReporter) pauseLocked() {
+	fmt.Print("\r" + strings.Repeat(" ", 60) + "\r")
+}
+
+// WithPaused erases the progress line, runs fn (typically printing a
+// streamed result), then redraws the progress line, all while holding
+// the lock that also guards the background ticker's redraws. This is
+// the terminal-safe way to interleave output with the progress line;
+// a Pause()/Redraw() pair around fn has a gap the ticker can land in.
+func (p *ProgressReporter) WithPaused(fn func()) {
+	if !p.enabled {
+		fn()
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pauseLocked()
+	fn()
+	p.redrawLocked()
+}
+
+// Stop halts the background ticker (if any) and erases the progress
+// line one last time. Safe to call more than once.
+func (p *ProgressReporter) Stop() {
+	p.stopOnce.Do(func() {
+		if p.ticker != nil {
+			p.ticker.Stop()
+			close(p.stop)
+		}
+	})
+	p.Pause()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProgressReporterIncrement(t *testing.T) {
+	var total atomic.Int64
+	total.Store(3)
+	p := newProgressReporter(false, &total)
+
+	p.Increment()
+	p.Increment()
+
+	if got := p.processed.Load(); got != 2 {
+		t.Fatalf("expected processed=2, got %d", got)
+	}
+}
+
+func TestWithPausedRunsFnWhenDisabled(t *testing.T) {
+	var total atomic.Int64
+	p := newProgressReporter(false, &total)
+
+	ran := false
+	p.WithPaused(func() { ran = true })
+
+	if !ran {
+		t.Fatal("expected WithPaused to still run fn when the reporter is disabled")
+	}
+}
+
+// TestWithPausedHoldsLockDuringFn is the regression test for the
+// ticker/WithPaused race: fn must run while mu is held, so a
+// concurrent ticker-driven Redraw() can't land in the middle of it.
+func TestWithPausedHoldsLockDuringFn(t *testing.T) {
+	var total atomic.Int64
+	p := newProgressReporter(true, &total)
+
+	var lockedDuringFn bool
+	p.WithPaused(func() {
+		lockedDuringFn = !p.mu.TryLock()
+	})
+
+	if !lockedDuringFn {
+		t.Fatal("expected mu to be held for the duration of WithPaused's fn")
+	}
+}
+
+// TestRedrawBlocksUntilWithPausedReleases confirms Redraw (as called by
+// the background ticker) actually waits for an in-flight WithPaused
+// call rather than interleaving with it.
+func TestRedrawBlocksUntilWithPausedReleases(t *testing.T) {
+	var total atomic.Int64
+	p := newProgressReporter(true, &total)
+
+	fnStarted := make(chan struct{})
+	releaseFn := make(chan struct{})
+	withPausedDone := make(chan struct{})
+	go func() {
+		p.WithPaused(func() {
+			close(fnStarted)
+			<-releaseFn
+		})
+		close(withPausedDone)
+	}()
+	<-fnStarted
+
+	redrawReturned := make(chan struct{})
+	go func() {
+		p.Redraw()
+		close(redrawReturned)
+	}()
+
+	select {
+	case <-redrawReturned:
+		t.Fatal("Redraw returned before the in-flight WithPaused call released the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(releaseFn)
+	<-withPausedDone
+	<-redrawReturned
+}
+
+func TestStopIsIdempotent(t *testing.T) {
+	var total atomic.Int64
+	p := newProgressReporter(true, &total)
+	p.Start(p.Redraw)
+
+	p.Stop()
+	p.Stop()
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider enriches a single IP address with additional data (geolocation,
+// ASN ownership, abuse reports, ...). Each provider's result is surfaced
+// as its own named section in the printed/JSON output, keyed by Name().
+type Provider interface {
+	Name() string
+	Enrich(ctx context.Context, ip string) (map[string]interface{}, error)
+}
+
+var providerRegistry = map[string]Provider{}
+
+func registerProvider(p Provider) {
+	providerRegistry[p.Name()] = p
+}
+
+func init() {
+	registerProvider(&ipinfoProvider{})
+	registerProvider(&ircnetILineProvider{})
+	registerProvider(&ripeStatProvider{})
+	registerProvider(&teamCymruProvider{})
+	registerProvider(&abuseIPDBProvider{})
+}
+
+// sortedProviderNames lists every registered provider, alphabetically, so
+// --help output and error messages are stable.
+func sortedProviderNames() []string {
+	names := make([]string, 0, len(providerRegistry))
+	for name := range providerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveProviders turns the --providers/--exclude-providers flags into the
+// concrete, ordered list of providers a lookup should run.
+func resolveProviders(include, exclude string) ([]Provider, error) {
+	includeNames := splitProviderList(include)
+	if len(includeNames) == 0 {
+		includeNames = []string{"ipinfo", "ircnet-iline"}
+	}
+	excludeSet := make(map[string]bool)
+	for _, name := range splitProviderList(exclude) {
+		excludeSet[name] = true
+	}
+
+	var providers []Provider
+	for _, name := range includeNames {
+		if excludeSet[name] {
+			continue
+		}
+		p, ok := providerRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(sortedProviderNames(), ", "))
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+func splitProviderList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	names := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// providerTimeout returns the timeout configured for a provider in
+// ~/.config/chick/config.yaml, falling back to def when unset.
+func providerTimeout(name string, def time.Duration) time.Duration {
+	if appConfig == nil {
+		return def
+	}
+	if cfg, ok := appConfig.Providers[name]; ok && cfg.Timeout > 0 {
+		return cfg.Timeout
+	}
+	return def
+}
+
+// ipinfoProvider wraps ipinfo.io's free geolocation/organization lookup.
+type ipinfoProvider struct{}
+
+func (p *ipinfoProvider) Name() string { return "ipinfo" }
+
+func (p *ipinfoProvider) Enrich(ctx context.Context, ip string) (map[string]interface{}, error) {
+	ipInfo, err := getIPInfo(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"country": ipInfo.Country,
+		"org":     ipInfo.Org,
+	}, nil
+}
+
+// ircnetILineProvider wraps bot.ircnet.info's I-line lookup.
+type ircnetILineProvider struct{}
+
+func (p *ircnetILineProvider) Name() string { return "ircnet-iline" }
+
+func (p *ircnetILineProvider) Enrich(ctx context.Context, ip string) (map[string]interface{}, error) {
+	servers, err := getILineInfo(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"servers": servers}, nil
+}
+
+// ripeStatProvider queries RIPEstat's whois data for an IP, which covers
+// allocations outside ARIN/ipinfo's usual coverage (e.g. RIPE/APNIC space).
+type ripeStatProvider struct{}
+
+func (p *ripeStatProvider) Name() string { return "ripe-stat" }
+
+func (p *ripeStatProvider) Enrich(ctx context.Context, ip string) (map[string]interface{}, error) {
+	enrichCtx, cancel := context.WithTimeout(ctx, providerTimeout(p.Name(), 10*time.Second))
+	defer cancel()
+
+	url := "https://stat.ripe.net/data/whois/data.json?resource=" + ip
+	req, err := http.NewRequestWithContext(enrichCtx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: providerTimeout(p.Name(), 10*time.Second)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parsed struct {
+		Data struct {
+			Records [][]struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"records"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	for _, record := range parsed.Data.Records {
+		for _, kv := range record {
+			switch strings.ToLower(kv.Key) {
+			case "netname", "descr", "origin", "country":
+				if _, exists := fields[strings.ToLower(kv.Key)]; !exists {
+					fields[strings.ToLower(kv.Key)] = kv.Value
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+// teamCymruProvider resolves the originating ASN for an IP via Team
+// Cymru's DNS-based whois service, by querying a reversed-octet TXT
+// record under origin.asn.cymru.com.
+type teamCymruProvider struct{}
+
+func (p *teamCymruProvider) Name() string { return "team-cymru" }
+
+func (p *teamCymruProvider) Enrich(ctx context.Context, ip string) (map[string]interface{}, error) {
+	query, err := cymruOriginQuery(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := net.DefaultResolver.LookupTXT(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("team-cymru lookup failed: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("team-cymru: no records returned for %s", ip)
+	}
+
+	// Response format: "ASN | BGP Prefix | CC | Registry | Allocated"
+	fields := strings.Split(records[0], "|")
+	result := map[string]interface{}{}
+	if len(fields) > 0 {
+		result["asn"] = strings.TrimSpace(fields[0])
+	}
+	if len(fields) > 1 {
+		result["prefix"] = strings.TrimSpace(fields[1])
+	}
+	if len(fields) > 2 {
+		result["country"] = strings.TrimSpace(fields[2])
+	}
+	if len(fields) > 3 {
+		result["registry"] = strings.TrimSpace(fields[3])
+	}
+	return result, nil
+}
+
+// cymruOriginQuery builds the reversed-octet query name Team Cymru's
+// DNS whois expects, e.g. 8.8.8.8 -> 8.8.8.8.origin.asn.cymru.com.
+func cymruOriginQuery(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP %q", ip)
+	}
+	v4 := parsed.To4()
+	if v4 == nil {
+		return "", fmt.Errorf("team-cymru: IPv6 lookups are not supported")
+	}
+	octets := make([]string, len(v4))
+	for i, b := range v4 {
+		octets[len(v4)-1-i] = strconv.Itoa(int(b))
+	}
+	return strings.Join(octets, ".") + ".origin.asn.cymru.com", nil
+}
+
+// abuseIPDBProvider queries AbuseIPDB's confidence score for an IP.
+// Requires --abuseipdb-key (or providers.abuseipdb.api_key in the config
+// file), since the API is authenticated.
+type abuseIPDBProvider struct{}
+
+func (p *abuseIPDBProvider) Name() string { return "abuseipdb" }
+
+func (p *abuseIPDBProvider) Enrich(ctx context.Context, ip string) (map[string]interface{}, error) {
+	key := CLI.AbuseIPDBKey
+	if key == "" && appConfig != nil {
+		key = appConfig.Providers[p.Name()].APIKey
+	}
+	if key == "" {
+		return nil, fmt.Errorf("abuseipdb: missing API key, pass --abuseipdb-key")
+	}
+
+	enrichCtx, cancel := context.WithTimeout(ctx, providerTimeout(p.Name(), 10*time.Second))
+	defer cancel()
+
+	url := "https://api.abuseipdb.com/api/v2/check?ipAddress=" + ip + "&maxAgeInDays=90"
+	req, err := http.NewRequestWithContext(enrichCtx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Key", key)
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: providerTimeout(p.Name(), 10*time.Second)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parsed struct {
+		Data struct {
+			AbuseConfidenceScore int    `json:"abuseConfidenceScore"`
+			TotalReports         int    `json:"totalReports"`
+			CountryCode          string `json:"countryCode"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"confidence_score": parsed.Data.AbuseConfidenceScore,
+		"total_reports":    parsed.Data.TotalReports,
+		"country_code":     parsed.Data.CountryCode,
+	}, nil
+}
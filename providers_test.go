@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestResolveProvidersDefaultsToIPInfoAndILine(t *testing.T) {
+	providers, err := resolveProviders("", "")
+	if err != nil {
+		t.Fatalf("resolveProviders returned error: %v", err)
+	}
+	var names []string
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	if len(names) != 2 || names[0] != "ipinfo" || names[1] != "ircnet-iline" {
+		t.Fatalf("expected default [ipinfo ircnet-iline], got %v", names)
+	}
+}
+
+func TestResolveProvidersHonorsExclude(t *testing.T) {
+	providers, err := resolveProviders("ipinfo,ircnet-iline", "ircnet-iline")
+	if err != nil {
+		t.Fatalf("resolveProviders returned error: %v", err)
+	}
+	if len(providers) != 1 || providers[0].Name() != "ipinfo" {
+		t.Fatalf("expected only ipinfo after exclude, got %v", providers)
+	}
+}
+
+func TestResolveProvidersRejectsUnknownName(t *testing.T) {
+	if _, err := resolveProviders("not-a-real-provider", ""); err == nil {
+		t.Fatal("expected error for unknown provider, got nil")
+	}
+}
+
+func TestSplitProviderListTrimsAndDropsEmpty(t *testing.T) {
+	got := splitProviderList(" ipinfo ,, team-cymru ,")
+	want := []string{"ipinfo", "team-cymru"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSplitProviderListEmptyString(t *testing.T) {
+	if got := splitProviderList("   "); got != nil {
+		t.Fatalf("expected nil for blank input, got %v", got)
+	}
+}
+
+func TestProviderTimeoutFallsBackWhenUnconfigured(t *testing.T) {
+	prev := appConfig
+	defer func() { appConfig = prev }()
+	appConfig = nil
+
+	if got := providerTimeout("ipinfo", 7); got != 7 {
+		t.Fatalf("expected fallback 7, got %v", got)
+	}
+}
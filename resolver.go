@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	dnsTypeA    = 1
+	dnsTypeAAAA = 28
+	dnsTypePTR  = 12
+	dnsClassIN  = 1
+)
+
+// queryFunc sends a raw DNS wire-format query and returns the raw
+// wire-format response, regardless of transport (UDP, TCP, DoH, DoT).
+type queryFunc func(ctx context.Context, query []byte) ([]byte, error)
+
+// dnsResolver answers A/AAAA/PTR questions by building DNS messages by
+// hand and sending them through query, rather than going through the
+// host's configured resolver. This is what lets --resolver/--doh/--dot
+// bypass the OS resolver entirely.
+type dnsResolver struct {
+	query queryFunc
+}
+
+// resolverInstance is the active custom resolver, or nil to use the
+// system resolver (net.LookupIP/net.LookupAddr) as before.
+var resolverInstance *dnsResolver
+
+// buildResolver constructs the resolver configured by --resolver, --doh,
+// --dot and --resolvers-file. Returns (nil, nil) when none are set, in
+// which case callers should fall back to the system resolver.
+func buildResolver() (*dnsResolver, error) {
+	if CLI.ResolversFile != "" {
+		addrs, err := readResolversFile(CLI.ResolversFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("resolvers file %q contains no resolvers", CLI.ResolversFile)
+		}
+		resolvers := make([]*dnsResolver, 0, len(addrs))
+		for _, addr := range addrs {
+			r, err := newPlainDNSResolver(addr)
+			if err != nil {
+				return nil, err
+			}
+			resolvers = append(resolvers, r)
+		}
+		return roundRobinResolver(resolvers), nil
+	}
+
+	if CLI.DOH != "" {
+		return newDoHResolver(CLI.DOH), nil
+	}
+
+	if CLI.DOT != "" {
+		return newDoTResolver(CLI.DOT), nil
+	}
+
+	if CLI.Resolver != "" {
+		return newPlainDNSResolver(CLI.Resolver)
+	}
+
+	return nil, nil
+}
+
+func readResolversFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening resolvers file: %v", err)
+	}
+	defer f.Close()
+
+	var addrs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addrs = append(addrs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading resolvers file: %v", err)
+	}
+	return addrs, nil
+}
+
+// roundRobinResolver distributes queries across several resolvers in
+// round-robin order, so a large scan isn't bottlenecked (or rate
+// limited) by a single upstream server.
+func roundRobinResolver(resolvers []*dnsResolver) *dnsResolver {
+	var counter atomic.Uint64
+	return &dnsResolver{
+		query: func(ctx context.Context, q []byte) ([]byte, error) {
+			i := counter.Add(1) - 1
+			return resolvers[int(i)%len(resolvers)].query(ctx, q)
+		},
+	}
+}
+
+// newPlainDNSResolver builds a resolver for a plain DNS server address.
+// "1.1.1.1:53" queries over UDP (falling back to TCP on truncation);
+// "tcp://9.9.9.9:53" forces TCP.
+func newPlainDNSResolver(addr string) (*dnsResolver, error) {
+	network := "udp"
+	if strings.HasPrefix(addr, "tcp://") {
+		network = "tcp"
+		addr = strings.TrimPrefix(addr, "tcp://")
+	} else if strings.HasPrefix(addr, "udp://") {
+		addr = strings.TrimPrefix(addr, "udp://")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid resolver address %q: %v", addr, err)
+	}
+
+	return &dnsResolver{
+		query: func(ctx context.Context, q []byte) ([]byte, error) {
+			resp, truncated, err := queryPlainDNS(ctx, network, addr, q)
+			if err != nil {
+				return nil, err
+			}
+			if truncated && network == "udp" {
+				resp, _, err = queryPlainDNS(ctx, "tcp", addr, q)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return resp, nil
+		},
+	}, nil
+}
+
+func queryPlainDNS(ctx context.Context, network, addr string, query []byte) ([]byte, bool, error) {
+	dialer := net.Dialer{Timeout: CLI.Timeout}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, false, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(CLI.Timeout))
+	}
+
+	if network == "tcp" {
+		if err := writeTCPFramed(conn, query); err != nil {
+			return nil, false, err
+		}
+		resp, err := readTCPFramed(conn)
+		return resp, false, err
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, false, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := n >= 12 && buf[2]&0x02 != 0
+	return buf[:n], truncated, nil
+}
+
+// newDoHResolver builds a resolver that POSTs RFC 8484 DNS-over-HTTPS
+// queries (application/dns-message) to url.
+func newDoHResolver(dohURL string) *dnsResolver {
+	client := &http.Client{Timeout: CLI.Timeout}
+	return &dnsResolver{
+		query: func(ctx context.Context, q []byte) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, "POST", dohURL, bytes.NewReader(q))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/dns-message")
+			req.Header.Set("Accept", "application/dns-message")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("doh: server returned status %d", resp.StatusCode)
+			}
+
+			var buf bytes.Buffer
+			if _, err := buf.ReadFrom(resp.Body); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+	}
+}
+
+// newDoTResolver builds a resolver that speaks DNS-over-TLS: a TLS
+// connection to host:853, with each message framed by a 2-byte length
+// prefix exactly like DNS-over-TCP.
+func newDoTResolver(addr string) *dnsResolver {
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: CLI.Timeout}}
+	return &dnsResolver{
+		query: func(ctx context.Context, q []byte) ([]byte, error) {
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return nil, fmt.Errorf("dot: dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			if deadline, ok := ctx.Deadline(); ok {
+				conn.SetDeadline(deadline)
+			} else {
+				conn.SetDeadline(time.Now().Add(CLI.Timeout))
+			}
+
+			if err := writeTCPFramed(conn, q); err != nil {
+				return nil, err
+			}
+			return readTCPFramed(conn)
+		},
+	}
+}
+
+func writeTCPFramed(conn net.Conn, msg []byte) error {
+	var lenBuf [2]byte
+	binary.BigEndian.PutUint16(lenBuf[:], uint16(len(msg)))
+	if _, err := conn.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := conn.Write(msg)
+	return err
+}
+
+func readTCPFramed(conn net.Conn) ([]byte, error) {
+	var lenBuf [2]byte
+	if _, err := readFull(conn, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(lenBuf[:]))
+	if _, err := readFull(conn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// lookupIPAddr resolves A and/or AAAA records for host, honouring
+// CLI.IPv4/CLI.IPv6 the same way the system-resolver path does.
+func (r *dnsResolver) lookupIPAddr(ctx context.Context, host string) ([]net.IP, error) {
+	var ips []net.IP
+	if !CLI.IPv6 {
+		a, err := r.lookupTyped(ctx, host, dnsTypeA)
+		if err != nil && CLI.IPv4 {
+			return nil, err
+		}
+		ips = append(ips, a...)
+	}
+	if !CLI.IPv4 {
+		aaaa, err := r.lookupTyped(ctx, host, dnsTypeAAAA)
+		if err != nil && CLI.IPv6 {
+			return nil, err
+		}
+		ips = append(ips, aaaa...)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no A/AAAA records found for %s", host)
+	}
+	return ips, nil
+}
+
+func (r *dnsResolver) lookupTyped(ctx context.Context, host string, qtype uint16) ([]net.IP, error) {
+	query, id := buildDNSQuery(host, qtype)
+	resp, err := r.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := parseDNSResponse(resp, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, a := range answers {
+		if a.rrtype != qtype {
+			continue
+		}
+		ips = append(ips, net.IP(a.rdata))
+	}
+	return ips, nil
+}
+
+// lookupAddr resolves PTR records for ip, mirroring net.LookupAddr.
+func (r *dnsResolver) lookupAddr(ctx context.Context, ip string) ([]string, error) {
+	name, err := reverseDNSName(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	query, id := buildDNSQuery(name, dnsTypePTR)
+	resp, err := r.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := parseDNSResponse(resp, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, a := range answers {
+		if a.rrtype != dnsTypePTR {
+			continue
+		}
+		ptrName, _, err := decodeName(a.raw, a.rdataOffset)
+		if err != nil {
+			continue
+		}
+		names = append(names, ptrName+".")
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no PTR records found for %s", ip)
+	}
+	return names, nil
+}
+
+// reverseDNSName builds the in-addr.arpa/ip6.arpa query name for ip.
+func reverseDNSName(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP %q", ip)
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	v6 := parsed.To16()
+	nibbles := make([]string, 0, 32)
+	for i := len(v6) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, strconv.FormatInt(int64(v6[i]&0x0F), 16))
+		nibbles = append(nibbles, strconv.FormatInt(int64(v6[i]>>4), 16))
+	}
+	return strings.Join(nibbles, ".") + ".ip6.arpa", nil
+}
+
+// --- Minimal DNS wire-format encode/decode (RFC 1035 section 4) ---
+
+// buildDNSQuery encodes a query for name/qtype and returns it along
+// with the random transaction ID it used, so the caller can verify the
+// response actually answers this query rather than some other one that
+// happened to arrive on the same socket. The ID is drawn from
+// crypto/rand rather than math/rand since it's the only thing stopping
+// an off-path attacker from spoofing a UDP response for this query.
+func buildDNSQuery(name string, qtype uint16) ([]byte, uint16) {
+	var buf bytes.Buffer
+	var header [12]byte
+	id := randomDNSID()
+	binary.BigEndian.PutUint16(header[0:2], id)
+	binary.BigEndian.PutUint16(header[2:4], 0x0100) // RD
+	binary.BigEndian.PutUint16(header[4:6], 1)      // QDCOUNT
+	buf.Write(header[:])
+	buf.Write(encodeDNSName(name))
+	var qtypeClass [4]byte
+	binary.BigEndian.PutUint16(qtypeClass[0:2], qtype)
+	binary.BigEndian.PutUint16(qtypeClass[2:4], dnsClassIN)
+	buf.Write(qtypeClass[:])
+	return buf.Bytes(), id
+}
+
+// randomDNSID returns a cryptographically random 16-bit transaction ID.
+// Falling back to a fixed ID on read failure would make every query
+// trivially spoofable, so treat that as fatal instead.
+func randomDNSID() uint16 {
+	var b [2]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("dns: failed to generate transaction ID: %v", err))
+	}
+	return binary.BigEndian.Uint16(b[:])
+}
+
+func encodeDNSName(name string) []byte {
+	var buf bytes.Buffer
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		buf.WriteByte(byte(len(label)))
+		buf.WriteString(label)
+	}
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+// dnsAnswer is one parsed resource record from a response's answer
+// section. raw/rdataOffset are kept so PTR names (which may use
+// message compression pointing earlier in the packet) can be decoded.
+type dnsAnswer struct {
+	rrtype      uint16
+	rdata       []byte
+	raw         []byte
+	rdataOffset int
+}
+
+// parseDNSResponse parses an answer section from a response to a query
+// that used transaction ID wantID, rejecting the response outright if
+// the ID doesn't match — without this check, any response arriving on
+// the socket (a late/duplicate reply, or a cache-poisoning attempt)
+// would be accepted as the answer to the current query.
+func parseDNSResponse(data []byte, wantID uint16) ([]dnsAnswer, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("dns: response too short")
+	}
+	if gotID := binary.BigEndian.Uint16(data[0:2]); gotID != wantID {
+		return nil, fmt.Errorf("dns: response ID %d does not match query ID %d", gotID, wantID)
+	}
+	if rcode := data[3] & 0x0F; rcode != 0 {
+		return nil, fmt.Errorf("dns: server returned rcode %d", rcode)
+	}
+
+	qdcount := binary.BigEndian.Uint16(data[4:6])
+	ancount := binary.BigEndian.Uint16(data[6:8])
+
+	offset := 12
+	for i := 0; i < int(qdcount); i++ {
+		next, err := skipName(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4 // QTYPE + QCLASS
+	}
+
+	answers := make([]dnsAnswer, 0, ancount)
+	for i := 0; i < int(ancount); i++ {
+		next, err := skipName(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next
+		if offset+10 > len(data) {
+			return nil, fmt.Errorf("dns: truncated answer record")
+		}
+		rrtype := binary.BigEndian.Uint16(data[offset : offset+2])
+		offset += 8 // TYPE + CLASS + TTL
+		rdlength := int(binary.BigEndian.Uint16(data[offset : offset+2]))
+		offset += 2
+		if offset+rdlength > len(data) {
+			return nil, fmt.Errorf("dns: truncated rdata")
+		}
+		answers = append(answers, dnsAnswer{
+			rrtype:      rrtype,
+			rdata:       data[offset : offset+rdlength],
+			raw:         data,
+			rdataOffset: offset,
+		})
+		offset += rdlength
+	}
+	return answers, nil
+}
+
+func skipName(data []byte, offset int) (int, error) {
+	_, next, err := decodeName(data, offset)
+	return next, err
+}
+
+// decodeName reads a (possibly compressed) domain name starting at
+// offset and returns it along with the offset immediately after it in
+// the original message.
+func decodeName(data []byte, offset int) (string, int, error) {
+	var labels []string
+	jumped := false
+	next := -1
+	jumps := 0
+
+	for {
+		if offset >= len(data) {
+			return "", 0, fmt.Errorf("dns: name extends past end of message")
+		}
+		length := data[offset]
+		if length == 0 {
+			offset++
+			break
+		}
+		if length&0xC0 == 0xC0 {
+			if offset+1 >= len(data) {
+				return "", 0, fmt.Errorf("dns: truncated name pointer")
+			}
+			if !jumped {
+				next = offset + 2
+				jumped = true
+			}
+			jumps++
+			if jumps > 20 {
+				return "", 0, fmt.Errorf("dns: too many compression pointers")
+			}
+			offset = int(binary.BigEndian.Uint16(data[offset:offset+2]) & 0x3FFF)
+			continue
+		}
+		offset++
+		if offset+int(length) > len(data) {
+			return "", 0, fmt.Errorf("dns: label extends past end of message")
+		}
+		labels = append(labels, string(data[offset:offset+int(length)]))
+		offset += int(length)
+	}
+
+	if jumped {
+		offset = next
+	}
+	return strings.Join(labels, "."), offset, nil
+}
+
+// validate that a --doh value parses as a URL early, rather than
+// failing confusingly on the first query.
+func validateDoHURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("invalid --doh URL %q", raw)
+	}
+	return nil
+}
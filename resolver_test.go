@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// buildTestResponse assembles a minimal, well-formed DNS response with
+// a single answer of the given type, using message compression to
+// point the answer's name back at the question — mirroring what real
+// resolvers send and exercising the same code path decodeName does.
+func buildTestResponse(id uint16, qname string, qtype uint16, rdata []byte) []byte {
+	var msg []byte
+	var header [12]byte
+	binary.BigEndian.PutUint16(header[0:2], id)
+	binary.BigEndian.PutUint16(header[4:6], 1) // QDCOUNT
+	binary.BigEndian.PutUint16(header[6:8], 1) // ANCOUNT
+	msg = append(msg, header[:]...)
+	msg = append(msg, encodeDNSName(qname)...)
+
+	var qtypeClass [4]byte
+	binary.BigEndian.PutUint16(qtypeClass[0:2], qtype)
+	binary.BigEndian.PutUint16(qtypeClass[2:4], dnsClassIN)
+	msg = append(msg, qtypeClass[:]...)
+
+	msg = append(msg, 0xC0, 0x0C) // pointer to the name at offset 12
+	var rr [10]byte
+	binary.BigEndian.PutUint16(rr[0:2], qtype)
+	binary.BigEndian.PutUint16(rr[2:4], dnsClassIN)
+	binary.BigEndian.PutUint16(rr[8:10], uint16(len(rdata)))
+	msg = append(msg, rr[:]...)
+	msg = append(msg, rdata...)
+	return msg
+}
+
+func TestParseDNSResponseReturnsAnswer(t *testing.T) {
+	resp := buildTestResponse(1234, "example.com", dnsTypeA, []byte{93, 184, 216, 34})
+
+	answers, err := parseDNSResponse(resp, 1234)
+	if err != nil {
+		t.Fatalf("parseDNSResponse returned error: %v", err)
+	}
+	if len(answers) != 1 {
+		t.Fatalf("expected 1 answer, got %d", len(answers))
+	}
+	if answers[0].rrtype != dnsTypeA {
+		t.Fatalf("expected rrtype A, got %d", answers[0].rrtype)
+	}
+	if got := net.IP(answers[0].rdata).String(); got != "93.184.216.34" {
+		t.Fatalf("unexpected rdata: %v", answers[0].rdata)
+	}
+}
+
+func TestParseDNSResponseRejectsMismatchedID(t *testing.T) {
+	resp := buildTestResponse(1234, "example.com", dnsTypeA, []byte{93, 184, 216, 34})
+
+	if _, err := parseDNSResponse(resp, 5678); err == nil {
+		t.Fatal("expected error for mismatched transaction ID, got nil")
+	}
+}
+
+func TestParseDNSResponseRejectsRcodeError(t *testing.T) {
+	resp := buildTestResponse(1234, "example.com", dnsTypeA, []byte{93, 184, 216, 34})
+	resp[3] |= 0x03 // NXDOMAIN
+
+	if _, err := parseDNSResponse(resp, 1234); err == nil {
+		t.Fatal("expected error for non-zero rcode, got nil")
+	}
+}
+
+func TestDecodeNameFollowsCompressionPointer(t *testing.T) {
+	qname := "host.example.com"
+	resp := buildTestResponse(1, qname, dnsTypePTR, []byte{})
+
+	answers, err := parseDNSResponse(resp, 1)
+	if err != nil {
+		t.Fatalf("parseDNSResponse returned error: %v", err)
+	}
+
+	// The answer's name is a compression pointer back at the question
+	// (written right after the 12-byte header + encoded qname + 4 bytes
+	// of QTYPE/QCLASS); decode from there to exercise pointer-following.
+	pointerOffset := 12 + len(encodeDNSName(qname)) + 4
+	name, next, err := decodeName(answers[0].raw, pointerOffset)
+	if err != nil {
+		t.Fatalf("decodeName returned error: %v", err)
+	}
+	if name != qname {
+		t.Fatalf("expected %q, got %q", qname, name)
+	}
+	if next != pointerOffset+2 {
+		t.Fatalf("expected pointer to consume 2 bytes, offset landed at %d", next)
+	}
+}
+
+func TestReverseDNSNameIPv4(t *testing.T) {
+	got, err := reverseDNSName("192.0.2.1")
+	if err != nil {
+		t.Fatalf("reverseDNSName returned error: %v", err)
+	}
+	want := "1.2.0.192.in-addr.arpa"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestReverseDNSNameIPv6(t *testing.T) {
+	got, err := reverseDNSName("2001:db8::1")
+	if err != nil {
+		t.Fatalf("reverseDNSName returned error: %v", err)
+	}
+	want := "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestReverseDNSNameInvalid(t *testing.T) {
+	if _, err := reverseDNSName("not-an-ip"); err == nil {
+		t.Fatal("expected error for invalid IP, got nil")
+	}
+}
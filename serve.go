@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	chickpb "github.com/kofany/chick/proto/chickpb"
+	"google.golang.org/grpc"
+)
+
+// ServeCmd runs chick as a long-lived process exposing the same lookup
+// functionality as an API, via gRPC and a small REST endpoint, so other
+// tools can query it without paying DNS/HTTP client setup cost on every
+// invocation.
+type ServeCmd struct {
+	GRPCAddr string        `help:"Address for the gRPC listener" default:":9090"`
+	HTTPAddr string        `help:"Address for the REST listener" default:":8080"`
+	CacheTTL time.Duration `help:"How long to cache a resolved IP's enrichment data (0 disables caching)" default:"60s"`
+}
+
+// Run starts the gRPC and HTTP listeners and blocks until either fails.
+func (s *ServeCmd) Run() error {
+	providers, err := resolveProviders(CLI.Providers, CLI.ExcludeProviders)
+	if err != nil {
+		return err
+	}
+	httpClient = &http.Client{Timeout: CLI.Timeout}
+
+	cache := newResultCache(s.CacheTTL)
+	svc := &chickServiceServer{defaultProviders: providers, cache: cache}
+
+	lis, err := net.Listen("tcp", s.GRPCAddr)
+	if err != nil {
+		return fmt.Errorf("error starting gRPC listener: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	chickpb.RegisterChickServiceServer(grpcServer, svc)
+
+	errChan := make(chan error, 2)
+	go func() {
+		fmt.Printf("%s: gRPC listening on %s\n", cyan("chick serve"), s.GRPCAddr)
+		errChan <- grpcServer.Serve(lis)
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lookup", svc.httpLookupHandler)
+	httpServer := &http.Server{Addr: s.HTTPAddr, Handler: mux}
+
+	go func() {
+		fmt.Printf("%s: HTTP listening on %s\n", cyan("chick serve"), s.HTTPAddr)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+
+	// Setup signal handling
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-sigChan:
+		fmt.Println("\nInterrupt received, shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+		grpcServer.GracefulStop()
+		return nil
+	}
+}
+
+// chickServiceServer implements chickpb.ChickServiceServer and backs
+// the REST /lookup endpoint, sharing the same cache and provider list
+// across both transports.
+type chickServiceServer struct {
+	chickpb.UnimplementedChickServiceServer
+	defaultProviders []Provider
+	cache            *resultCache
+}
+
+// Lookup resolves req.Target and streams one LookupResult per IP as its
+// enrichment completes.
+func (s *chickServiceServer) Lookup(req *chickpb.LookupRequest, stream chickpb.ChickService_LookupServer) error {
+	ctx := stream.Context()
+
+	providers := s.defaultProviders
+	if len(req.Providers) > 0 {
+		p, err := providersByName(req.Providers)
+		if err != nil {
+			return err
+		}
+		providers = p
+	}
+
+	for _, result := range s.lookupAll(ctx, req.Target, req.Ipv4Only, req.Ipv6Only, providers) {
+		if err := stream.Send(resultToProto(result)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupAll resolves target to its IPs and enriches each one, serving
+// cached entries where available.
+func (s *chickServiceServer) lookupAll(ctx context.Context, target string, ipv4Only, ipv6Only bool, providers []Provider) []Result {
+	ips, err := lookupIPs(ctx, target)
+	if err != nil {
+		return []Result{{IP: target, OriginTarget: target, Error: fmt.Errorf("error resolving target: %v", err)}}
+	}
+
+	var results []Result
+	for _, ip := range ips {
+		isIPv6 := ip.To4() == nil
+		if (ipv4Only && isIPv6) || (ipv6Only && !isIPv6) {
+			continue
+		}
+
+		if cached, ok := s.cache.get(ip.String(), providers); ok {
+			results = append(results, cached)
+			continue
+		}
+
+		result := lookupIP(ctx, lookupTask{ip: ip.String(), isIPv6: isIPv6, originTarget: target}, providers)
+		s.cache.set(ip.String(), providers, result)
+		results = append(results, result)
+	}
+	return results
+}
+
+// httpLookupHandler implements GET /lookup?target=...&format=ndjson,
+// the REST equivalent of the gRPC Lookup call.
+func (s *chickServiceServer) httpLookupHandler(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "missing required query param: target", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "json" {
+		http.Error(w, fmt.Sprintf("unsupported format %q: use json or ndjson", format), http.StatusBadRequest)
+		return
+	}
+
+	results := s.lookupAll(r.Context(), target, CLI.IPv4, CLI.IPv6, s.defaultProviders)
+
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		payload := make([]resultJSON, 0, len(results))
+		for _, result := range results {
+			payload = append(payload, toResultJSON(result))
+		}
+		json.NewEncoder(w).Encode(payload)
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		for _, result := range results {
+			enc.Encode(toResultJSON(result))
+		}
+	}
+}
+
+// providersByName resolves a list of provider names against the
+// registry, used when a gRPC request asks for a specific subset.
+func providersByName(names []string) ([]Provider, error) {
+	providers := make([]Provider, 0, len(names))
+	for _, name := range names {
+		p, ok := providerRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// resultToProto converts our internal Result into the wire type sent
+// over gRPC/the REST endpoint.
+func resultToProto(result Result) *chickpb.LookupResult {
+	lr := &chickpb.LookupResult{
+		Ip:           result.IP,
+		Ptr:          result.PTR,
+		IsIpv6:       result.IsIPv6,
+		OriginTarget: result.OriginTarget,
+		Providers:    make(map[string]*chickpb.ProviderResult, len(result.Providers)),
+	}
+	if result.Error != nil {
+		lr.Error = result.Error.Error()
+	}
+	for name, data := range result.Providers {
+		fields := make(map[string]string, len(data))
+		for k, v := range data {
+			fields[k] = fmt.Sprintf("%v", v)
+		}
+		lr.Providers[name] = &chickpb.ProviderResult{Fields: fields}
+	}
+	return lr
+}